@@ -0,0 +1,46 @@
+package main
+
+import "fmt"
+
+// Cache is the storage backend used to persist data fetched from the
+// Tencent Cloud API during a preload run. Implementations must be safe
+// for concurrent use, since regions are synced from multiple goroutines
+// in parallel. Every method takes a credentialID so rows from different
+// Tencent Cloud accounts never collide in a shared backend.
+type Cache interface {
+	PutRegions(credentialID string, regions []Region) error
+	PutZones(credentialID, region string, zones []Zone) error
+	PutImages(credentialID, region string, images []Image) error
+	UpsertInstance(credentialID string, inst Instance) error
+	MarkInstancesStale(credentialID string) error
+
+	PutVpcs(credentialID, region string, vpcs []Vpc) error
+	PutSubnets(credentialID, region string, subnets []Subnet) error
+	PutSecurityGroups(credentialID, region string, sgs []SecurityGroup) error
+	PutKeyPairs(credentialID, region string, keyPairs []KeyPair) error
+	PutInstanceTypeConfigs(credentialID, region, zone string, configs []InstanceTypeConfig) error
+
+	// The Has* lookups let the instance-create endpoint validate a
+	// request against what the sync layer has already cached, without
+	// every caller needing its own read path into each backend.
+	HasImage(credentialID, region, imageID string) (bool, error)
+	HasInstanceType(credentialID, region, zone, instanceType string) (bool, error)
+	HasSubnet(credentialID, region, subnetID string) (bool, error)
+	HasSecurityGroup(credentialID, region, securityGroupID string) (bool, error)
+}
+
+// newCache builds the Cache implementation selected by --cache-type.
+// maxSize only applies to the memory backend, where it bounds the number
+// of instances kept before the LRU starts evicting.
+func newCache(cacheType string, maxSize int) (Cache, error) {
+	switch cacheType {
+	case "", "sqlite":
+		return newSQLiteCache()
+	case "memory":
+		return newMemoryCache(maxSize), nil
+	case "redis":
+		return newRedisCache()
+	default:
+		return nil, fmt.Errorf("unknown cache type %q (want memory|sqlite|redis)", cacheType)
+	}
+}
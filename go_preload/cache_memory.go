@@ -0,0 +1,191 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+// memoryCache is an in-process LRU cache, useful for local development or
+// single-replica deployments that don't want a SQLite file or a Redis
+// dependency. Regions/zones/images are small and kept in full; instances
+// are bounded by maxSize via LRU eviction since a busy account can have a
+// large and growing instance count. Every map is keyed by credentialID (or
+// credentialID+"/"+region) so accounts never see each other's rows.
+type memoryCache struct {
+	mu sync.Mutex
+
+	regions        map[string][]Region
+	zones          map[string][]Zone               // keyed by "credentialID/region"
+	images         map[string][]Image              // keyed by "credentialID/region"
+	vpcs           map[string][]Vpc                // keyed by "credentialID/region"
+	subnets        map[string][]Subnet             // keyed by "credentialID/region"
+	securityGroups map[string][]SecurityGroup      // keyed by "credentialID/region"
+	keyPairs       map[string][]KeyPair            // keyed by "credentialID/region"
+	instanceTypes  map[string][]InstanceTypeConfig // keyed by "credentialID/region/zone"
+
+	maxSize   int
+	instances map[string]*list.Element // "credentialID/instance_id" -> node in lru
+	lru       *list.List               // front = most recently used
+}
+
+type memoryInstanceEntry struct {
+	key          string
+	credentialID string
+	inst         Instance
+}
+
+func newMemoryCache(maxSize int) *memoryCache {
+	if maxSize <= 0 {
+		maxSize = 10000
+	}
+	return &memoryCache{
+		regions:        make(map[string][]Region),
+		zones:          make(map[string][]Zone),
+		images:         make(map[string][]Image),
+		vpcs:           make(map[string][]Vpc),
+		subnets:        make(map[string][]Subnet),
+		securityGroups: make(map[string][]SecurityGroup),
+		keyPairs:       make(map[string][]KeyPair),
+		instanceTypes:  make(map[string][]InstanceTypeConfig),
+		maxSize:        maxSize,
+		instances:      make(map[string]*list.Element),
+		lru:            list.New(),
+	}
+}
+
+func (c *memoryCache) PutRegions(credentialID string, regions []Region) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.regions[credentialID] = append([]Region(nil), regions...)
+	return nil
+}
+
+func (c *memoryCache) PutZones(credentialID, region string, zones []Zone) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.zones[credentialID+"/"+region] = append([]Zone(nil), zones...)
+	return nil
+}
+
+func (c *memoryCache) PutImages(credentialID, region string, images []Image) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.images[credentialID+"/"+region] = append([]Image(nil), images...)
+	return nil
+}
+
+func (c *memoryCache) UpsertInstance(credentialID string, inst Instance) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := credentialID + "/" + inst.InstanceID
+	if el, ok := c.instances[key]; ok {
+		el.Value.(*memoryInstanceEntry).inst = inst
+		c.lru.MoveToFront(el)
+		return nil
+	}
+
+	el := c.lru.PushFront(&memoryInstanceEntry{key: key, credentialID: credentialID, inst: inst})
+	c.instances[key] = el
+
+	for c.lru.Len() > c.maxSize {
+		oldest := c.lru.Back()
+		if oldest == nil {
+			break
+		}
+		c.lru.Remove(oldest)
+		delete(c.instances, oldest.Value.(*memoryInstanceEntry).key)
+	}
+	return nil
+}
+
+func (c *memoryCache) MarkInstancesStale(credentialID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, el := range c.instances {
+		entry := el.Value.(*memoryInstanceEntry)
+		if entry.credentialID == credentialID {
+			entry.inst.Status = "-1"
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) HasImage(credentialID, region, imageID string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, img := range c.images[credentialID+"/"+region] {
+		if img.ImageID == imageID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *memoryCache) HasInstanceType(credentialID, region, zone, instanceType string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cfg := range c.instanceTypes[credentialID+"/"+region+"/"+zone] {
+		if cfg.InstanceType == instanceType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *memoryCache) HasSubnet(credentialID, region, subnetID string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sn := range c.subnets[credentialID+"/"+region] {
+		if sn.SubnetID == subnetID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *memoryCache) HasSecurityGroup(credentialID, region, securityGroupID string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, sg := range c.securityGroups[credentialID+"/"+region] {
+		if sg.SecurityGroupID == securityGroupID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *memoryCache) PutVpcs(credentialID, region string, vpcs []Vpc) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vpcs[credentialID+"/"+region] = append([]Vpc(nil), vpcs...)
+	return nil
+}
+
+func (c *memoryCache) PutSubnets(credentialID, region string, subnets []Subnet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subnets[credentialID+"/"+region] = append([]Subnet(nil), subnets...)
+	return nil
+}
+
+func (c *memoryCache) PutSecurityGroups(credentialID, region string, sgs []SecurityGroup) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.securityGroups[credentialID+"/"+region] = append([]SecurityGroup(nil), sgs...)
+	return nil
+}
+
+func (c *memoryCache) PutKeyPairs(credentialID, region string, keyPairs []KeyPair) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keyPairs[credentialID+"/"+region] = append([]KeyPair(nil), keyPairs...)
+	return nil
+}
+
+func (c *memoryCache) PutInstanceTypeConfigs(credentialID, region, zone string, configs []InstanceTypeConfig) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.instanceTypes[credentialID+"/"+region+"/"+zone] = append([]InstanceTypeConfig(nil), configs...)
+	return nil
+}
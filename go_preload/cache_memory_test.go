@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestMemoryCacheLRUEvictsOldestPastMaxSize(t *testing.T) {
+	c := newMemoryCache(3)
+	const credentialID = "cred-1"
+
+	for i, id := range []string{"ins-1", "ins-2", "ins-3"} {
+		if err := c.UpsertInstance(credentialID, Instance{InstanceID: id}); err != nil {
+			t.Fatalf("UpsertInstance(%d): %v", i, err)
+		}
+	}
+	if got := c.lru.Len(); got != 3 {
+		t.Fatalf("lru.Len() = %d, want 3", got)
+	}
+
+	// Touch ins-1 so it becomes most-recently-used and survives the next
+	// insert, which should evict ins-2 (now the oldest) instead.
+	if err := c.UpsertInstance(credentialID, Instance{InstanceID: "ins-1"}); err != nil {
+		t.Fatalf("UpsertInstance(touch ins-1): %v", err)
+	}
+	if err := c.UpsertInstance(credentialID, Instance{InstanceID: "ins-4"}); err != nil {
+		t.Fatalf("UpsertInstance(ins-4): %v", err)
+	}
+
+	if got := c.lru.Len(); got != 3 {
+		t.Fatalf("lru.Len() after eviction = %d, want 3", got)
+	}
+	if _, ok := c.instances[credentialID+"/ins-2"]; ok {
+		t.Fatal("ins-2 should have been evicted as the least recently used entry")
+	}
+	for _, id := range []string{"ins-1", "ins-3", "ins-4"} {
+		if _, ok := c.instances[credentialID+"/"+id]; !ok {
+			t.Fatalf("%s should still be cached", id)
+		}
+	}
+}
+
+func TestMemoryCacheDefaultMaxSize(t *testing.T) {
+	c := newMemoryCache(0)
+	if c.maxSize != 10000 {
+		t.Fatalf("maxSize = %d, want default 10000", c.maxSize)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TTLs for the Redis-backed cache. Regions/zones change rarely, images are
+// refreshed a bit more often, and instances are the most volatile so they
+// get the shortest TTL.
+const (
+	regionsTTL  = 24 * time.Hour
+	zonesTTL    = 24 * time.Hour
+	imagesTTL   = 6 * time.Hour
+	instanceTTL = 1 * time.Hour
+)
+
+// redisCache stores each entity in its own Redis hash, one key per entity
+// (e.g. cvm:{credentialID}:region:{id}, cvm:{credentialID}:image:{region}:{id}),
+// following the same one-hash-per-entity shape UpsertInstance already used.
+// A hash field's value must be a scalar, so entities with multiple
+// sub-fields can't be packed as a single field on a shared per-region hash.
+// Useful when multiple preload-server replicas need to share one cache.
+type redisCache struct {
+	client *redis.Client
+}
+
+func newRedisCache() (*redisCache, error) {
+	addr := os.Getenv("CVM_REDIS_ADDR")
+	if addr == "" {
+		addr = "127.0.0.1:6379"
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("CVM_REDIS_PASSWORD"),
+	})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("connect redis failed: %w", err)
+	}
+	return &redisCache{client: client}, nil
+}
+
+// replacePrefix deletes every existing key matching pattern, then writes
+// entries (key -> hash fields) with the given TTL. It's the shared
+// put-many implementation for every entity kind below.
+func (c *redisCache) replacePrefix(ctx context.Context, pattern string, entries map[string]map[string]interface{}, ttl time.Duration) error {
+	if err := c.deleteByPattern(ctx, pattern); err != nil {
+		return err
+	}
+	for key, fields := range entries {
+		if err := c.client.HSet(ctx, key, fields).Err(); err != nil {
+			return err
+		}
+		if err := c.client.Expire(ctx, key, ttl).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *redisCache) deleteByPattern(ctx context.Context, pattern string) error {
+	var cursor uint64
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := c.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func (c *redisCache) PutRegions(credentialID string, regions []Region) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(regions))
+	for _, r := range regions {
+		entries[fmt.Sprintf("cvm:%s:region:%s", credentialID, r.Region)] = map[string]interface{}{
+			"region_name":  r.RegionName,
+			"region_state": r.RegionState,
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:region:*", credentialID), entries, regionsTTL)
+}
+
+func (c *redisCache) PutZones(credentialID, region string, zones []Zone) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(zones))
+	for _, z := range zones {
+		entries[fmt.Sprintf("cvm:%s:zone:%s:%s", credentialID, region, z.Zone)] = map[string]interface{}{
+			"zone_name":  z.ZoneName,
+			"zone_state": z.ZoneState,
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:zone:%s:*", credentialID, region), entries, zonesTTL)
+}
+
+func (c *redisCache) PutImages(credentialID, region string, images []Image) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(images))
+	for _, img := range images {
+		entries[fmt.Sprintf("cvm:%s:image:%s:%s", credentialID, region, img.ImageID)] = map[string]interface{}{
+			"image_name":   img.ImageName,
+			"image_type":   img.ImageType,
+			"platform":     img.Platform,
+			"created_time": img.CreatedTime,
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:image:%s:*", credentialID, region), entries, imagesTTL)
+}
+
+func (c *redisCache) UpsertInstance(credentialID string, inst Instance) error {
+	ctx := context.Background()
+	key := fmt.Sprintf("cvm:%s:instance:%s", credentialID, inst.InstanceID)
+	fields := map[string]interface{}{
+		"instance_name": inst.InstanceName,
+		"status":        inst.Status,
+		"region":        inst.Region,
+		"zone":          inst.Zone,
+		"instance_type": inst.InstanceType,
+		"image_id":      inst.ImageID,
+		"cpu":           strconv.FormatInt(inst.CPU, 10),
+		"memory":        strconv.FormatInt(inst.Memory, 10),
+		"private_ip":    inst.PrivateIP,
+		"public_ip":     inst.PublicIP,
+		"created_time":  inst.CreatedTime,
+	}
+	if err := c.client.HSet(ctx, key, fields).Err(); err != nil {
+		return err
+	}
+	return c.client.Expire(ctx, key, instanceTTL).Err()
+}
+
+func (c *redisCache) HasImage(credentialID, region, imageID string) (bool, error) {
+	n, err := c.client.Exists(context.Background(), fmt.Sprintf("cvm:%s:image:%s:%s", credentialID, region, imageID)).Result()
+	return n > 0, err
+}
+
+func (c *redisCache) HasInstanceType(credentialID, region, zone, instanceType string) (bool, error) {
+	n, err := c.client.Exists(context.Background(), fmt.Sprintf("cvm:%s:instance_type_config:%s:%s:%s", credentialID, region, zone, instanceType)).Result()
+	return n > 0, err
+}
+
+func (c *redisCache) HasSubnet(credentialID, region, subnetID string) (bool, error) {
+	n, err := c.client.Exists(context.Background(), fmt.Sprintf("cvm:%s:subnet:%s:%s", credentialID, region, subnetID)).Result()
+	return n > 0, err
+}
+
+func (c *redisCache) HasSecurityGroup(credentialID, region, securityGroupID string) (bool, error) {
+	n, err := c.client.Exists(context.Background(), fmt.Sprintf("cvm:%s:security_group:%s:%s", credentialID, region, securityGroupID)).Result()
+	return n > 0, err
+}
+
+func (c *redisCache) PutVpcs(credentialID, region string, vpcs []Vpc) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(vpcs))
+	for _, v := range vpcs {
+		entries[fmt.Sprintf("cvm:%s:vpc:%s:%s", credentialID, region, v.VpcID)] = map[string]interface{}{
+			"name":       v.Name,
+			"cidr_block": v.CidrBlock,
+			"is_default": strconv.FormatBool(v.IsDefault),
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:vpc:%s:*", credentialID, region), entries, zonesTTL)
+}
+
+func (c *redisCache) PutSubnets(credentialID, region string, subnets []Subnet) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(subnets))
+	for _, sn := range subnets {
+		entries[fmt.Sprintf("cvm:%s:subnet:%s:%s", credentialID, region, sn.SubnetID)] = map[string]interface{}{
+			"vpc_id":             sn.VpcID,
+			"name":               sn.Name,
+			"cidr_block":         sn.CidrBlock,
+			"zone":               sn.Zone,
+			"available_ip_count": strconv.FormatInt(sn.AvailableIPCount, 10),
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:subnet:%s:*", credentialID, region), entries, zonesTTL)
+}
+
+func (c *redisCache) PutSecurityGroups(credentialID, region string, sgs []SecurityGroup) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(sgs))
+	for _, sg := range sgs {
+		entries[fmt.Sprintf("cvm:%s:security_group:%s:%s", credentialID, region, sg.SecurityGroupID)] = map[string]interface{}{
+			"name":        sg.Name,
+			"description": sg.Description,
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:security_group:%s:*", credentialID, region), entries, zonesTTL)
+}
+
+func (c *redisCache) PutKeyPairs(credentialID, region string, keyPairs []KeyPair) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(keyPairs))
+	for _, kp := range keyPairs {
+		entries[fmt.Sprintf("cvm:%s:key_pair:%s:%s", credentialID, region, kp.KeyID)] = map[string]interface{}{
+			"key_name":    kp.KeyName,
+			"description": kp.Description,
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:key_pair:%s:*", credentialID, region), entries, zonesTTL)
+}
+
+func (c *redisCache) PutInstanceTypeConfigs(credentialID, region, zone string, configs []InstanceTypeConfig) error {
+	ctx := context.Background()
+	entries := make(map[string]map[string]interface{}, len(configs))
+	for _, cfg := range configs {
+		entries[fmt.Sprintf("cvm:%s:instance_type_config:%s:%s:%s", credentialID, region, zone, cfg.InstanceType)] = map[string]interface{}{
+			"cpu":             strconv.FormatInt(cfg.CPU, 10),
+			"memory":          strconv.FormatInt(cfg.Memory, 10),
+			"instance_family": cfg.InstanceFamily,
+		}
+	}
+	return c.replacePrefix(ctx, fmt.Sprintf("cvm:%s:instance_type_config:%s:%s:*", credentialID, region, zone), entries, imagesTTL)
+}
+
+func (c *redisCache) MarkInstancesStale(credentialID string) error {
+	ctx := context.Background()
+	var cursor uint64
+	pattern := fmt.Sprintf("cvm:%s:instance:*", credentialID)
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, 100).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := c.client.HSet(ctx, key, "status", "-1").Err(); err != nil {
+				return err
+			}
+			// HSet recreates the hash with no expiry if the key's TTL lapsed
+			// between Scan and here, leaving an immortal zombie key behind.
+			// Re-set it every time; redoing it on a key that never expired
+			// is harmless.
+			if err := c.client.Expire(ctx, key, instanceTTL).Err(); err != nil {
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteCache is the original storage backend: a local SQLite file shared
+// by the whole process, written with the same tx/prepare/exec pattern the
+// rest of the codebase already uses. Every table is namespaced by
+// credential_id so multiple Tencent Cloud accounts can share one file.
+type sqliteCache struct {
+	db *sql.DB
+}
+
+func newSQLiteCache() (*sqliteCache, error) {
+	dbPath, err := resolveDBPath()
+	if err != nil {
+		return nil, err
+	}
+
+	// 增加写等待，防止数据库忙
+	db, err := sql.Open("sqlite3", dbPath+"?_journal=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("open db failed: %w", err)
+	}
+	return &sqliteCache{db: db}, nil
+}
+
+func (c *sqliteCache) PutRegions(credentialID string, regions []Region) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM regions WHERE credential_id = ?", credentialID)
+	stmt, err := tx.Prepare("INSERT INTO regions (credential_id, region, region_name, region_state, updated_at) VALUES (?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, r := range regions {
+		if _, err := stmt.Exec(credentialID, r.Region, r.RegionName, r.RegionState); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *sqliteCache) PutZones(credentialID, region string, zones []Zone) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM zones WHERE credential_id = ? AND region = ?", credentialID, region)
+	stmt, err := tx.Prepare("INSERT INTO zones (credential_id, zone, region, zone_name, zone_state, updated_at) VALUES (?, ?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, z := range zones {
+		if _, err := stmt.Exec(credentialID, z.Zone, region, z.ZoneName, z.ZoneState); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *sqliteCache) PutImages(credentialID, region string, images []Image) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM images WHERE credential_id = ? AND region = ? AND image_type = 'PUBLIC_IMAGE'", credentialID, region)
+	stmt, err := tx.Prepare("INSERT INTO images (credential_id, image_id, image_name, image_type, platform, region, created_time, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, img := range images {
+		if _, err := stmt.Exec(credentialID, img.ImageID, img.ImageName, img.ImageType, img.Platform, region, img.CreatedTime); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *sqliteCache) UpsertInstance(credentialID string, inst Instance) error {
+	_, err := c.db.Exec(`INSERT INTO instances (credential_id, instance_id, instance_name, status, region, zone, instance_type, image_id, cpu, memory, private_ip, public_ip, created_time, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, strftime('%s','now'))
+		ON CONFLICT(credential_id, instance_id) DO UPDATE SET status=excluded.status, updated_at=strftime('%s','now')`,
+		credentialID, inst.InstanceID, inst.InstanceName, inst.Status, inst.Region, inst.Zone, inst.InstanceType, inst.ImageID, inst.CPU, inst.Memory, inst.PrivateIP, inst.PublicIP, inst.CreatedTime)
+	return err
+}
+
+func (c *sqliteCache) MarkInstancesStale(credentialID string) error {
+	_, err := c.db.Exec("UPDATE instances SET status='-1', updated_at=strftime('%s','now') WHERE credential_id = ? AND status != '-1'", credentialID)
+	return err
+}
+
+func (c *sqliteCache) HasImage(credentialID, region, imageID string) (bool, error) {
+	var exists bool
+	err := c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM images WHERE credential_id = ? AND region = ? AND image_id = ?)", credentialID, region, imageID).Scan(&exists)
+	return exists, err
+}
+
+func (c *sqliteCache) HasInstanceType(credentialID, region, zone, instanceType string) (bool, error) {
+	var exists bool
+	err := c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM instance_type_configs WHERE credential_id = ? AND region = ? AND zone = ? AND instance_type = ?)", credentialID, region, zone, instanceType).Scan(&exists)
+	return exists, err
+}
+
+func (c *sqliteCache) HasSubnet(credentialID, region, subnetID string) (bool, error) {
+	var exists bool
+	err := c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM subnets WHERE credential_id = ? AND region = ? AND subnet_id = ?)", credentialID, region, subnetID).Scan(&exists)
+	return exists, err
+}
+
+func (c *sqliteCache) HasSecurityGroup(credentialID, region, securityGroupID string) (bool, error) {
+	var exists bool
+	err := c.db.QueryRow("SELECT EXISTS(SELECT 1 FROM security_groups WHERE credential_id = ? AND region = ? AND security_group_id = ?)", credentialID, region, securityGroupID).Scan(&exists)
+	return exists, err
+}
+
+func (c *sqliteCache) PutVpcs(credentialID, region string, vpcs []Vpc) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM vpcs WHERE credential_id = ? AND region = ?", credentialID, region)
+	stmt, err := tx.Prepare("INSERT INTO vpcs (credential_id, vpc_id, region, name, cidr_block, is_default, updated_at) VALUES (?, ?, ?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, v := range vpcs {
+		if _, err := stmt.Exec(credentialID, v.VpcID, region, v.Name, v.CidrBlock, v.IsDefault); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *sqliteCache) PutSubnets(credentialID, region string, subnets []Subnet) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM subnets WHERE credential_id = ? AND region = ?", credentialID, region)
+	stmt, err := tx.Prepare("INSERT INTO subnets (credential_id, subnet_id, vpc_id, region, name, cidr_block, zone, available_ip_count, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, sn := range subnets {
+		if _, err := stmt.Exec(credentialID, sn.SubnetID, sn.VpcID, region, sn.Name, sn.CidrBlock, sn.Zone, sn.AvailableIPCount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *sqliteCache) PutSecurityGroups(credentialID, region string, sgs []SecurityGroup) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM security_groups WHERE credential_id = ? AND region = ?", credentialID, region)
+	stmt, err := tx.Prepare("INSERT INTO security_groups (credential_id, security_group_id, region, name, description, updated_at) VALUES (?, ?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, sg := range sgs {
+		if _, err := stmt.Exec(credentialID, sg.SecurityGroupID, region, sg.Name, sg.Description); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *sqliteCache) PutKeyPairs(credentialID, region string, keyPairs []KeyPair) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM key_pairs WHERE credential_id = ? AND region = ?", credentialID, region)
+	stmt, err := tx.Prepare("INSERT INTO key_pairs (credential_id, key_id, region, key_name, description, updated_at) VALUES (?, ?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, kp := range keyPairs {
+		if _, err := stmt.Exec(credentialID, kp.KeyID, region, kp.KeyName, kp.Description); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *sqliteCache) PutInstanceTypeConfigs(credentialID, region, zone string, configs []InstanceTypeConfig) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, _ = tx.Exec("DELETE FROM instance_type_configs WHERE credential_id = ? AND region = ? AND zone = ?", credentialID, region, zone)
+	stmt, err := tx.Prepare("INSERT INTO instance_type_configs (credential_id, region, zone, instance_type, cpu, memory, instance_family, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, strftime('%s','now'))")
+	if err != nil {
+		return err
+	}
+	for _, cfg := range configs {
+		if _, err := stmt.Exec(credentialID, region, zone, cfg.InstanceType, cfg.CPU, cfg.Memory, cfg.InstanceFamily); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// CredentialRequest is the body for POST /credentials.
+type CredentialRequest struct {
+	Name          string `json:"name"`
+	SecretID      string `json:"secret_id"`
+	SecretKey     string `json:"secret_key"`
+	DefaultRegion string `json:"default_region"`
+}
+
+// CredentialView is what /credentials returns: never the encrypted
+// secret key, since there's no legitimate reason for a client to read it
+// back.
+type CredentialView struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	SecretID      string `json:"secret_id"`
+	DefaultRegion string `json:"default_region"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+func toCredentialView(c Credential) CredentialView {
+	return CredentialView{ID: c.ID, Name: c.Name, SecretID: c.SecretID, DefaultRegion: c.DefaultRegion, CreatedAt: c.CreatedAt}
+}
+
+// handleCredentials dispatches POST /credentials (create) and GET
+// /credentials (list).
+func (s *server) handleCredentials(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var req CredentialRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		if req.SecretID == "" || req.SecretKey == "" {
+			http.Error(w, "secret_id and secret_key are required", http.StatusBadRequest)
+			return
+		}
+		cred, err := s.credentials.Create(req.Name, req.SecretID, req.SecretKey, req.DefaultRegion)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(toCredentialView(cred))
+
+	case http.MethodGet:
+		creds, err := s.credentials.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		views := make([]CredentialView, 0, len(creds))
+		for _, c := range creds {
+			views = append(views, toCredentialView(c))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(views)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCredential dispatches GET/DELETE /credentials/{id}.
+func (s *server) handleCredential(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/credentials/")
+	if id == "" {
+		http.Error(w, "missing credential id", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		cred, err := s.credentials.Get(id)
+		if err != nil {
+			http.Error(w, "credential not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(toCredentialView(cred))
+
+	case http.MethodDelete:
+		if err := s.credentials.Delete(id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
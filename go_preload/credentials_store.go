@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// credentialsStore persists Tencent Cloud accounts in the credentials
+// table. It always uses SQLite regardless of --cache-type, since it's
+// small, relational, and needs to survive the process whether the chosen
+// Cache backend is memory, redis, or sqlite.
+type credentialsStore struct {
+	db  *sql.DB
+	key keyProvider
+}
+
+func newCredentialsStore() (*credentialsStore, error) {
+	dbPath, err := resolveDBPath()
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", dbPath+"?_journal=WAL&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("open db failed: %w", err)
+	}
+	return &credentialsStore{db: db, key: newEnvKeyProvider()}, nil
+}
+
+func (s *credentialsStore) Create(name, secretID, secretKey, defaultRegion string) (Credential, error) {
+	key, err := s.key.Key()
+	if err != nil {
+		return Credential{}, fmt.Errorf("credentials encryption key unavailable: %w", err)
+	}
+	encrypted, err := encryptSecretKey(key, secretKey)
+	if err != nil {
+		return Credential{}, fmt.Errorf("encrypt secret key failed: %w", err)
+	}
+
+	res, err := s.db.Exec(
+		"INSERT INTO credentials (name, secret_id, secret_key_encrypted, default_region, created_at) VALUES (?, ?, ?, ?, strftime('%s','now'))",
+		name, secretID, encrypted, defaultRegion,
+	)
+	if err != nil {
+		return Credential{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Credential{}, err
+	}
+	return s.Get(fmt.Sprintf("%d", id))
+}
+
+func (s *credentialsStore) Get(id string) (Credential, error) {
+	var c Credential
+	err := s.db.QueryRow(
+		"SELECT id, name, secret_id, secret_key_encrypted, default_region, created_at FROM credentials WHERE id = ?", id,
+	).Scan(&c.ID, &c.Name, &c.SecretID, &c.SecretKeyEncrypted, &c.DefaultRegion, &c.CreatedAt)
+	return c, err
+}
+
+func (s *credentialsStore) List() ([]Credential, error) {
+	rows, err := s.db.Query("SELECT id, name, secret_id, secret_key_encrypted, default_region, created_at FROM credentials ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Credential
+	for rows.Next() {
+		var c Credential
+		if err := rows.Scan(&c.ID, &c.Name, &c.SecretID, &c.SecretKeyEncrypted, &c.DefaultRegion, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (s *credentialsStore) Delete(id string) error {
+	_, err := s.db.Exec("DELETE FROM credentials WHERE id = ?", id)
+	return err
+}
+
+// Decrypt returns the plaintext secret key for a stored credential.
+func (s *credentialsStore) Decrypt(c Credential) (string, error) {
+	key, err := s.key.Key()
+	if err != nil {
+		return "", fmt.Errorf("credentials encryption key unavailable: %w", err)
+	}
+	return decryptSecretKey(key, c.SecretKeyEncrypted)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// keyProvider resolves the AES-GCM key used to encrypt credentials at
+// rest. It's an interface rather than a bare env lookup so a KMS-backed
+// provider can be dropped in later without touching callers.
+type keyProvider interface {
+	Key() ([]byte, error)
+}
+
+// envKeyProvider reads a base64-encoded 32-byte key from an environment
+// variable. This is the default provider; a KMS-backed one can implement
+// the same interface for production deployments.
+type envKeyProvider struct {
+	envVar string
+}
+
+func newEnvKeyProvider() *envKeyProvider {
+	return &envKeyProvider{envVar: "CVM_CREDENTIALS_KEY"}
+}
+
+func (p *envKeyProvider) Key() ([]byte, error) {
+	encoded := os.Getenv(p.envVar)
+	if encoded == "" {
+		return nil, fmt.Errorf("%s is not set", p.envVar)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid base64: %w", p.envVar, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s must decode to 32 bytes for AES-256, got %d", p.envVar, len(key))
+	}
+	return key, nil
+}
+
+func encryptSecretKey(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+func decryptSecretKey(key []byte, encoded string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
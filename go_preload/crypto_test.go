@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/rand"
+	"io"
+	"testing"
+)
+
+func mustKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return key
+}
+
+func TestEncryptDecryptSecretKeyRoundTrip(t *testing.T) {
+	key := mustKey(t)
+	const plaintext = "AKID-super-secret"
+
+	encoded, err := encryptSecretKey(key, plaintext)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	got, err := decryptSecretKey(key, encoded)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if got != plaintext {
+		t.Fatalf("decrypt got %q, want %q", got, plaintext)
+	}
+}
+
+func TestDecryptSecretKeyWrongKey(t *testing.T) {
+	encoded, err := encryptSecretKey(mustKey(t), "AKID-super-secret")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if _, err := decryptSecretKey(mustKey(t), encoded); err == nil {
+		t.Fatal("decrypt with wrong key succeeded, want error")
+	}
+}
+
+func TestDecryptSecretKeyShortCiphertext(t *testing.T) {
+	key := mustKey(t)
+	if _, err := decryptSecretKey(key, ""); err == nil {
+		t.Fatal("decrypt empty ciphertext succeeded, want error")
+	}
+	if _, err := decryptSecretKey(key, "YWJj"); err == nil {
+		t.Fatal("decrypt ciphertext shorter than the nonce succeeded, want error")
+	}
+}
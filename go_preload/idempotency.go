@@ -0,0 +1,66 @@
+package main
+
+import "sync"
+
+// idempotentResult is the replayed response for a repeated request.
+type idempotentResult struct {
+	status int
+	body   []byte
+}
+
+// idempotencyStore replays the first response for a given idempotency key
+// instead of re-running a mutating call, so a retried /instances/create
+// (or any lifecycle action) can't double-create or double-terminate.
+// Replaying only after the first call's response is stored isn't enough on
+// its own: two concurrent retries can both miss get() before either has
+// finished. begin reserves the key up front so the second caller blocks
+// until the first has stored its result, instead of racing it to the SDK.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	locks   map[string]*sync.Mutex
+	results map[string]idempotentResult
+}
+
+func newIdempotencyStore() *idempotencyStore {
+	return &idempotencyStore{locks: make(map[string]*sync.Mutex), results: make(map[string]idempotentResult)}
+}
+
+func (s *idempotencyStore) get(key string) (idempotentResult, bool) {
+	if key == "" {
+		return idempotentResult{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.results[key]
+	return r, ok
+}
+
+// begin reserves key for one in-flight call. The caller must invoke the
+// returned unlock once its result has been stored via put. A concurrent
+// call to begin with the same key blocks until unlock is called, so it
+// can then re-check get() and replay the result instead of repeating the
+// SDK call. If key is empty there's nothing to dedupe, so reserved is
+// false and unlock is a no-op.
+func (s *idempotencyStore) begin(key string) (unlock func(), reserved bool) {
+	if key == "" {
+		return func() {}, false
+	}
+	s.mu.Lock()
+	lock, ok := s.locks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.locks[key] = lock
+	}
+	s.mu.Unlock()
+	lock.Lock()
+	return lock.Unlock, true
+}
+
+func (s *idempotencyStore) put(key string, status int, body []byte) {
+	if key == "" {
+		return
+	}
+	s.mu.Lock()
+	s.results[key] = idempotentResult{status: status, body: body}
+	s.mu.Unlock()
+}
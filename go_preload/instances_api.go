@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// CreateInstanceRequest is the body for POST /instances/create.
+type CreateInstanceRequest struct {
+	SecretID         string   `json:"secret_id"`
+	SecretKey        string   `json:"secret_key"`
+	CredentialID     string   `json:"credential_id"`
+	Region           string   `json:"region"`
+	Zone             string   `json:"zone"`
+	ImageID          string   `json:"image_id"`
+	InstanceType     string   `json:"instance_type"`
+	VpcID            string   `json:"vpc_id"`
+	SubnetID         string   `json:"subnet_id"`
+	SecurityGroupIDs []string `json:"security_group_ids"`
+	Password         string   `json:"password"`
+	KeyIDs           []string `json:"key_ids"`
+	DataDiskType     string   `json:"data_disk_type"`
+	DataDiskSizeGB   int64    `json:"data_disk_size_gb"`
+	IdempotencyKey   string   `json:"idempotency_key"`
+}
+
+// InstanceActionRequest is the body for the start/stop/reboot/terminate/
+// reset_password endpoints.
+type InstanceActionRequest struct {
+	SecretID       string `json:"secret_id"`
+	SecretKey      string `json:"secret_key"`
+	CredentialID   string `json:"credential_id"`
+	Region         string `json:"region"`
+	Password       string `json:"password"`         // reset_password only
+	KeepImageLogin bool   `json:"keep_image_login"` // reset_password only
+	ForceStop      bool   `json:"force_stop"`       // stop only
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// ActionResponse is the common JSON shape returned by the instance
+// lifecycle endpoints.
+type ActionResponse struct {
+	Success     bool     `json:"success"`
+	Message     string   `json:"message"`
+	InstanceIDs []string `json:"instance_ids,omitempty"`
+	RequestID   string   `json:"request_id,omitempty"`
+}
+
+func (s *server) handleInstanceCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req CreateInstanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if cached, ok := s.idempotency.get(req.IdempotencyKey); ok {
+		writeRaw(w, cached.status, cached.body)
+		return
+	}
+	if unlock, reserved := s.idempotency.begin(req.IdempotencyKey); reserved {
+		defer unlock()
+		if cached, ok := s.idempotency.get(req.IdempotencyKey); ok {
+			writeRaw(w, cached.status, cached.body)
+			return
+		}
+	}
+
+	if err := s.resolveInstanceCredential(&req.SecretID, &req.SecretKey, req.CredentialID); err != nil {
+		writeAction(w, s.idempotency, req.IdempotencyKey, http.StatusBadRequest, ActionResponse{Message: err.Error()})
+		return
+	}
+	credentialID := credentialNamespace(PreloadRequest{SecretID: req.SecretID, CredentialID: req.CredentialID})
+
+	cache := s.cache
+
+	if msg, ok := s.validateCreate(cache, credentialID, req); !ok {
+		writeAction(w, s.idempotency, req.IdempotencyKey, http.StatusBadRequest, ActionResponse{Message: msg})
+		return
+	}
+
+	client, err := newCvmClient(req.SecretID, req.SecretKey, req.Region)
+	if err != nil {
+		writeAction(w, s.idempotency, req.IdempotencyKey, http.StatusInternalServerError, ActionResponse{Message: err.Error()})
+		return
+	}
+
+	sdkReq := cvm.NewRunInstancesRequest()
+	sdkReq.Placement = &cvm.Placement{Zone: common.StringPtr(req.Zone)}
+	sdkReq.ImageId = common.StringPtr(req.ImageID)
+	sdkReq.InstanceType = common.StringPtr(req.InstanceType)
+	sdkReq.InstanceCount = common.Int64Ptr(1)
+	if req.VpcID != "" || req.SubnetID != "" {
+		sdkReq.VirtualPrivateCloud = &cvm.VirtualPrivateCloud{VpcId: common.StringPtr(req.VpcID), SubnetId: common.StringPtr(req.SubnetID)}
+	}
+	if len(req.SecurityGroupIDs) > 0 {
+		sdkReq.SecurityGroupIds = common.StringPtrs(req.SecurityGroupIDs)
+	}
+	login := &cvm.LoginSettings{}
+	if req.Password != "" {
+		login.Password = common.StringPtr(req.Password)
+	}
+	if len(req.KeyIDs) > 0 {
+		login.KeyIds = common.StringPtrs(req.KeyIDs)
+	}
+	sdkReq.LoginSettings = login
+	if req.DataDiskType != "" || req.DataDiskSizeGB > 0 {
+		sdkReq.DataDisks = []*cvm.DataDisk{{DiskType: common.StringPtr(req.DataDiskType), DiskSize: common.Uint64Ptr(uint64(req.DataDiskSizeGB))}}
+	}
+
+	resp, err := client.RunInstances(sdkReq)
+	if err != nil {
+		writeAction(w, s.idempotency, req.IdempotencyKey, sdkErrorStatus(err), ActionResponse{Message: err.Error()})
+		return
+	}
+
+	ids := make([]string, 0, len(resp.Response.InstanceIdSet))
+	for _, idPtr := range resp.Response.InstanceIdSet {
+		id := str(idPtr)
+		ids = append(ids, id)
+		// Upsert a pending row immediately so clients don't have to wait
+		// for the next full preload to see the instance they just created.
+		_ = cache.UpsertInstance(credentialID, Instance{
+			InstanceID:   id,
+			Status:       "PENDING",
+			Region:       req.Region,
+			Zone:         req.Zone,
+			InstanceType: req.InstanceType,
+			ImageID:      req.ImageID,
+		})
+	}
+
+	writeAction(w, s.idempotency, req.IdempotencyKey, http.StatusOK, ActionResponse{Success: true, Message: "success", InstanceIDs: ids, RequestID: str(resp.Response.RequestId)})
+}
+
+// validateCreate checks the request against what the sync layer has
+// already cached, so a typo'd image/subnet/security-group id fails fast
+// instead of round-tripping to the Tencent Cloud API.
+func (s *server) validateCreate(cache Cache, credentialID string, req CreateInstanceRequest) (string, bool) {
+	if req.Region == "" || req.Zone == "" || req.ImageID == "" || req.InstanceType == "" {
+		return "region, zone, image_id and instance_type are required", false
+	}
+	if ok, _ := cache.HasImage(credentialID, req.Region, req.ImageID); !ok {
+		return fmt.Sprintf("unknown image_id %q in region %q", req.ImageID, req.Region), false
+	}
+	if ok, _ := cache.HasInstanceType(credentialID, req.Region, req.Zone, req.InstanceType); !ok {
+		return fmt.Sprintf("instance_type %q not offered in zone %q", req.InstanceType, req.Zone), false
+	}
+	if req.SubnetID != "" {
+		if ok, _ := cache.HasSubnet(credentialID, req.Region, req.SubnetID); !ok {
+			return fmt.Sprintf("unknown subnet_id %q in region %q", req.SubnetID, req.Region), false
+		}
+	}
+	for _, sgID := range req.SecurityGroupIDs {
+		if ok, _ := cache.HasSecurityGroup(credentialID, req.Region, sgID); !ok {
+			return fmt.Sprintf("unknown security group %q in region %q", sgID, req.Region), false
+		}
+	}
+	return "", true
+}
+
+// handleInstanceAction dispatches /instances/{id}/start|stop|reboot|terminate|reset_password.
+func (s *server) handleInstanceAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/instances/"), "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, "expected /instances/{id}/{action}", http.StatusNotFound)
+		return
+	}
+	instanceID, action := parts[0], parts[1]
+
+	var req InstanceActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	if cached, ok := s.idempotency.get(req.IdempotencyKey); ok {
+		writeRaw(w, cached.status, cached.body)
+		return
+	}
+	if unlock, reserved := s.idempotency.begin(req.IdempotencyKey); reserved {
+		defer unlock()
+		if cached, ok := s.idempotency.get(req.IdempotencyKey); ok {
+			writeRaw(w, cached.status, cached.body)
+			return
+		}
+	}
+
+	if err := s.resolveInstanceCredential(&req.SecretID, &req.SecretKey, req.CredentialID); err != nil {
+		writeAction(w, s.idempotency, req.IdempotencyKey, http.StatusBadRequest, ActionResponse{Message: err.Error()})
+		return
+	}
+
+	client, err := newCvmClient(req.SecretID, req.SecretKey, req.Region)
+	if err != nil {
+		writeAction(w, s.idempotency, req.IdempotencyKey, http.StatusInternalServerError, ActionResponse{Message: err.Error()})
+		return
+	}
+
+	var requestID string
+	var sdkErr error
+	switch action {
+	case "start":
+		sdkReq := cvm.NewStartInstancesRequest()
+		sdkReq.InstanceIds = common.StringPtrs([]string{instanceID})
+		resp, err := client.StartInstances(sdkReq)
+		sdkErr = err
+		if resp != nil {
+			requestID = str(resp.Response.RequestId)
+		}
+	case "stop":
+		sdkReq := cvm.NewStopInstancesRequest()
+		sdkReq.InstanceIds = common.StringPtrs([]string{instanceID})
+		if req.ForceStop {
+			sdkReq.StopType = common.StringPtr("HARD")
+		}
+		resp, err := client.StopInstances(sdkReq)
+		sdkErr = err
+		if resp != nil {
+			requestID = str(resp.Response.RequestId)
+		}
+	case "reboot":
+		sdkReq := cvm.NewRebootInstancesRequest()
+		sdkReq.InstanceIds = common.StringPtrs([]string{instanceID})
+		resp, err := client.RebootInstances(sdkReq)
+		sdkErr = err
+		if resp != nil {
+			requestID = str(resp.Response.RequestId)
+		}
+	case "terminate":
+		sdkReq := cvm.NewTerminateInstancesRequest()
+		sdkReq.InstanceIds = common.StringPtrs([]string{instanceID})
+		resp, err := client.TerminateInstances(sdkReq)
+		sdkErr = err
+		if resp != nil {
+			requestID = str(resp.Response.RequestId)
+		}
+	case "reset_password":
+		sdkReq := cvm.NewResetInstancesPasswordRequest()
+		sdkReq.InstanceIds = common.StringPtrs([]string{instanceID})
+		sdkReq.Password = common.StringPtr(req.Password)
+		sdkReq.KeepImageLogin = common.StringPtr(boolToYesNo(req.KeepImageLogin))
+		resp, err := client.ResetInstancesPassword(sdkReq)
+		sdkErr = err
+		if resp != nil {
+			requestID = str(resp.Response.RequestId)
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unknown action %q", action), http.StatusNotFound)
+		return
+	}
+
+	if sdkErr != nil {
+		writeAction(w, s.idempotency, req.IdempotencyKey, sdkErrorStatus(sdkErr), ActionResponse{Message: sdkErr.Error(), InstanceIDs: []string{instanceID}})
+		return
+	}
+
+	writeAction(w, s.idempotency, req.IdempotencyKey, http.StatusOK, ActionResponse{Success: true, Message: "success", InstanceIDs: []string{instanceID}, RequestID: requestID})
+}
+
+// resolveInstanceCredential fills in secretID/secretKey from a stored
+// credential when credentialID is set, mirroring (*server).resolveCredential
+// for the instance lifecycle endpoints, whose request bodies carry secrets
+// alongside other required fields rather than embedding a PreloadRequest.
+func (s *server) resolveInstanceCredential(secretID, secretKey *string, credentialID string) error {
+	if credentialID == "" {
+		return nil
+	}
+	cred, err := s.credentials.Get(credentialID)
+	if err != nil {
+		return fmt.Errorf("unknown credential_id %q", credentialID)
+	}
+	plain, err := s.credentials.Decrypt(cred)
+	if err != nil {
+		return fmt.Errorf("decrypt credential failed: %w", err)
+	}
+	*secretID = cred.SecretID
+	*secretKey = plain
+	return nil
+}
+
+func boolToYesNo(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
+
+func writeAction(w http.ResponseWriter, store *idempotencyStore, key string, status int, resp ActionResponse) {
+	body, _ := json.Marshal(resp)
+	store.put(key, status, body)
+	writeRaw(w, status, body)
+}
+
+func writeRaw(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
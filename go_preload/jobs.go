@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// preloadJob tracks one in-flight (or streamed) preload run so that
+// /preload_all/cancel can reach it by job_id.
+type preloadJob struct {
+	id       string
+	cancel   context.CancelFunc
+	progress *progressReporter
+}
+
+var (
+	jobsMu sync.Mutex
+	jobs   = make(map[string]*preloadJob)
+)
+
+// newPreloadJob derives a cancellable context from parent, registers it
+// under a fresh job_id, and returns the job handle. Callers must call
+// finishPreloadJob when the run completes.
+func newPreloadJob(parent context.Context) (*preloadJob, context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	job := &preloadJob{id: nextJobID(), cancel: cancel, progress: newProgressReporter()}
+
+	jobsMu.Lock()
+	jobs[job.id] = job
+	jobsMu.Unlock()
+
+	return job, ctx
+}
+
+func finishPreloadJob(job *preloadJob) {
+	job.cancel()
+	jobsMu.Lock()
+	delete(jobs, job.id)
+	jobsMu.Unlock()
+}
+
+func lookupPreloadJob(id string) (*preloadJob, bool) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	job, ok := jobs[id]
+	return job, ok
+}
+
+// cancelAllPreloadJobs is called on SIGINT/SIGTERM so in-flight preloads
+// stop picking up new region/stage work during the shutdown grace period
+// instead of being killed mid-run. It only cancels the context checked
+// between stages; it does not roll back any already-open cache transaction.
+func cancelAllPreloadJobs() {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	for _, job := range jobs {
+		job.cancel()
+	}
+}
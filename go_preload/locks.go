@@ -0,0 +1,37 @@
+package main
+
+import "sync"
+
+// credentialLocks hands out one mutex per credential_id, so two preloads
+// for the same account serialize while different accounts run fully in
+// parallel.
+type credentialLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func newCredentialLocks() *credentialLocks {
+	return &credentialLocks{locks: make(map[string]*sync.Mutex)}
+}
+
+func (l *credentialLocks) get(credentialID string) *sync.Mutex {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock, ok := l.locks[credentialID]
+	if !ok {
+		lock = &sync.Mutex{}
+		l.locks[credentialID] = lock
+	}
+	return lock
+}
+
+// credentialNamespace returns the identifier used to namespace cached rows
+// for a preload request: the stored credential_id if one was given,
+// otherwise a stable pseudo-id derived from the inline secret_id so ad hoc
+// credentials still get consistent namespacing across runs.
+func credentialNamespace(req PreloadRequest) string {
+	if req.CredentialID != "" {
+		return req.CredentialID
+	}
+	return "inline:" + req.SecretID
+}
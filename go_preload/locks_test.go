@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestCredentialNamespace(t *testing.T) {
+	cases := []struct {
+		name string
+		req  PreloadRequest
+		want string
+	}{
+		{"stored credential wins", PreloadRequest{CredentialID: "cred-1", SecretID: "AKID1"}, "cred-1"},
+		{"falls back to inline secret_id", PreloadRequest{SecretID: "AKID1"}, "inline:AKID1"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := credentialNamespace(c.req); got != c.want {
+				t.Errorf("credentialNamespace(%+v) = %q, want %q", c.req, got, c.want)
+			}
+		})
+	}
+}
+
+func TestCredentialLocksReturnsSameMutexForSameID(t *testing.T) {
+	l := newCredentialLocks()
+	a := l.get("cred-1")
+	b := l.get("cred-1")
+	if a != b {
+		t.Fatal("get() returned different mutexes for the same credential_id")
+	}
+	if other := l.get("cred-2"); other == a {
+		t.Fatal("get() returned the same mutex for different credential_ids")
+	}
+}
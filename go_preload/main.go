@@ -1,18 +1,22 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
@@ -22,6 +26,7 @@ type PreloadRequest struct {
 	SecretID      string `json:"secret_id"`
 	SecretKey     string `json:"secret_key"`
 	DefaultRegion string `json:"default_region"`
+	CredentialID  string `json:"credential_id"`
 }
 
 type PreloadResponse struct {
@@ -29,14 +34,94 @@ type PreloadResponse struct {
 	Message string `json:"message"`
 }
 
+// server holds the shared Cache backend used by every HTTP handler. cache
+// is constructed once at startup: building a fresh one per request would
+// make the memory backend's state (and any data validateCreate depends on)
+// vanish as soon as the handler returns.
+type server struct {
+	cache       Cache
+	idempotency *idempotencyStore
+	credentials *credentialsStore
+	locks       *credentialLocks
+}
+
 func main() {
 	initLogger()
-	http.HandleFunc("/preload_all", handlePreloadAll)
+
+	cacheType := flag.String("cache-type", "sqlite", "cache backend: memory|sqlite|redis")
+	cacheMaxSize := flag.Int("cache-max-size", 10000, "max entries kept by the in-memory LRU cache backend")
+
+	syncEnable := flag.Bool("sync-enable", false, "run regions/zones/images/instances sync on background tickers")
+	syncRegionsInterval := flag.Duration("sync-regions-interval", 24*time.Hour, "ticker interval for the regions sync job")
+	syncZonesInterval := flag.Duration("sync-zones-interval", 6*time.Hour, "ticker interval for the zones sync job")
+	syncImagesInterval := flag.Duration("sync-images-interval", time.Hour, "ticker interval for the images sync job")
+	syncInstancesInterval := flag.Duration("sync-instances-interval", 60*time.Second, "ticker interval for the instances sync job")
+	flag.Parse()
+
+	cache, err := newCache(*cacheType, *cacheMaxSize)
+	if err != nil {
+		log.Fatalf("failed to init cache backend: %v", err)
+	}
+
+	credentials, err := newCredentialsStore()
+	if err != nil {
+		log.Fatalf("failed to init credentials store: %v", err)
+	}
+
+	srv := &server{
+		cache:       cache,
+		idempotency: newIdempotencyStore(),
+		credentials: credentials,
+		locks:       newCredentialLocks(),
+	}
+
+	http.HandleFunc("/preload_all", srv.handlePreloadAll)
+	http.HandleFunc("/preload_all/stream", srv.handlePreloadAllStream)
+	http.HandleFunc("/preload_all/cancel", srv.handlePreloadAllCancel)
+	http.HandleFunc("/instances/create", srv.handleInstanceCreate)
+	http.HandleFunc("/instances/", srv.handleInstanceAction)
+	http.HandleFunc("/credentials", srv.handleCredentials)
+	http.HandleFunc("/credentials/", srv.handleCredential)
 	http.HandleFunc("/health", handleHealth)
 
+	if *syncEnable {
+		secretID := os.Getenv("TENCENTCLOUD_SECRET_ID")
+		secretKey := os.Getenv("TENCENTCLOUD_SECRET_KEY")
+		defaultRegion := os.Getenv("TENCENTCLOUD_DEFAULT_REGION")
+		if defaultRegion == "" {
+			defaultRegion = "ap-beijing"
+		}
+
+		sched := newScheduler(cache, secretID, secretKey, defaultRegion, schedulerIntervals{
+			Regions:   *syncRegionsInterval,
+			Zones:     *syncZonesInterval,
+			Images:    *syncImagesInterval,
+			Instances: *syncInstancesInterval,
+		})
+		sched.Start()
+
+		http.HandleFunc("/sync/status", sched.handleStatus)
+		http.HandleFunc("/sync/trigger", sched.handleTrigger)
+		log.Printf("background sync enabled (regions=%s zones=%s images=%s instances=%s)",
+			*syncRegionsInterval, *syncZonesInterval, *syncImagesInterval, *syncInstancesInterval)
+	}
+
 	addr := ":8088"
-	log.Printf("Go Preload Server (Safe Mode) listening on %s\n", addr)
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	httpServer := &http.Server{Addr: addr}
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		log.Printf("received shutdown signal, cancelling in-flight preloads...")
+		cancelAllPreloadJobs()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Go Preload Server (Safe Mode) listening on %s, cache-type=%s\n", addr, *cacheType)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("failed to start server: %v", err)
 	}
 }
@@ -58,7 +143,7 @@ func initLogger() {
 	log.SetPrefix("[GO] ")
 }
 
-func handlePreloadAll(w http.ResponseWriter, r *http.Request) {
+func (s *server) handlePreloadAll(w http.ResponseWriter, r *http.Request) {
 	// 增加 Panic 恢复，防止进程崩溃
 	defer func() {
 		if err := recover(); err != nil {
@@ -79,13 +164,28 @@ func handlePreloadAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	req, err := s.resolveCredential(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if req.DefaultRegion == "" {
 		req.DefaultRegion = "ap-beijing"
 	}
+	credentialID := credentialNamespace(req)
 
 	log.Printf("收到同步请求 (Region: %s)，正在执行...", req.DefaultRegion)
 
-	if err := runFullPreload(req); err != nil {
+	cache := s.cache
+
+	job, ctx := newPreloadJob(r.Context())
+	defer finishPreloadJob(job)
+
+	lock := s.locks.get(credentialID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := runFullPreload(ctx, cache, credentialID, req, job.progress); err != nil {
 		log.Printf("同步失败: %v", err)
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(PreloadResponse{Success: false, Message: err.Error()})
@@ -97,18 +197,43 @@ func handlePreloadAll(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(PreloadResponse{Success: true, Message: "success"})
 }
 
-func runFullPreload(req PreloadRequest) error {
-	dbPath, err := resolveDBPath()
+// resolveCredential fills in secret_id/secret_key/default_region from a
+// stored credential when the caller passed credential_id instead of inline
+// secrets. Inline requests pass through unchanged.
+func (s *server) resolveCredential(req PreloadRequest) (PreloadRequest, error) {
+	if req.CredentialID == "" {
+		return req, nil
+	}
+	cred, err := s.credentials.Get(req.CredentialID)
 	if err != nil {
-		return err
+		return req, fmt.Errorf("unknown credential_id %q", req.CredentialID)
 	}
-
-	// 增加写等待，防止数据库忙
-	db, err := sql.Open("sqlite3", dbPath+"?_journal=WAL&_busy_timeout=5000")
+	secretKey, err := s.credentials.Decrypt(cred)
 	if err != nil {
-		return fmt.Errorf("open db failed: %w", err)
+		return req, fmt.Errorf("decrypt credential failed: %w", err)
+	}
+	req.SecretID = cred.SecretID
+	req.SecretKey = secretKey
+	if req.DefaultRegion == "" {
+		req.DefaultRegion = cred.DefaultRegion
+	}
+	return req, nil
+}
+
+// runFullPreload fetches regions, zones, images and instances from the
+// Tencent Cloud API and writes them through the given Cache. It only
+// depends on the Cache interface so callers can point it at SQLite,
+// Redis, or the in-memory backend interchangeably.
+//
+// ctx is checked between every region/stage so a cancelled job (client
+// disconnect, SIGINT/SIGTERM, or an explicit /preload_all/cancel) stops
+// picking up new work instead of running to completion; the underlying
+// SDK calls themselves aren't context-aware, so in-flight calls still
+// finish naturally. progress may be nil if the caller doesn't care.
+func runFullPreload(ctx context.Context, cache Cache, credentialID string, req PreloadRequest, progress *progressReporter) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	defer db.Close()
 
 	client, err := newCvmClient(req.SecretID, req.SecretKey, req.DefaultRegion)
 	if err != nil {
@@ -120,11 +245,16 @@ func runFullPreload(req PreloadRequest) error {
 	if err != nil {
 		return fmt.Errorf("fetch regions failed: %w", err)
 	}
-	_ = syncRegionsToDB(db, regions)
+	if err := cache.PutRegions(credentialID, toRegions(regions)); err != nil {
+		log.Printf("cache.PutRegions failed: %v", err)
+	}
+	progress.Emit(ProgressEvent{Stage: "regions", Done: 1, Total: 1})
 
 	// 2. 并发同步
 	var wg sync.WaitGroup
 	sem := make(chan struct{}, 10)
+	var zonesDone, imagesDone int32
+	total := len(regions)
 	for _, r := range regions {
 		regionID := str(r.Region)
 		if regionID == "" {
@@ -136,14 +266,28 @@ func runFullPreload(req PreloadRequest) error {
 			sem <- struct{}{}
 			defer func() { <-sem }()
 
+			if ctx.Err() != nil {
+				return
+			}
+
 			rClient, err := newCvmClient(req.SecretID, req.SecretKey, rid)
 			if err != nil {
 				return
 			}
 
 			// 可用区
+			var zones []*cvm.ZoneInfo
 			if zResp, err := rClient.DescribeZones(cvm.NewDescribeZonesRequest()); err == nil {
-				_ = syncZonesToDB(db, rid, zResp.Response.ZoneSet)
+				zones = zResp.Response.ZoneSet
+				if err := cache.PutZones(credentialID, rid, toZones(zones)); err != nil {
+					log.Printf("cache.PutZones(%s) failed: %v", rid, err)
+				}
+			}
+			done := atomic.AddInt32(&zonesDone, 1)
+			progress.Emit(ProgressEvent{Stage: "zones", Region: rid, Done: int(done), Total: total})
+
+			if ctx.Err() != nil {
+				return
 			}
 
 			// 镜像
@@ -151,54 +295,65 @@ func runFullPreload(req PreloadRequest) error {
 			iReq.Limit = common.Uint64Ptr(60)
 			iReq.Filters = []*cvm.Filter{{Name: common.StringPtr("image-type"), Values: []*string{common.StringPtr("PUBLIC_IMAGE")}}}
 			if iResp, err := rClient.DescribeImages(iReq); err == nil {
-				_ = syncImagesToDB(db, rid, iResp.Response.ImageSet)
+				if err := cache.PutImages(credentialID, rid, toImages(iResp.Response.ImageSet)); err != nil {
+					log.Printf("cache.PutImages(%s) failed: %v", rid, err)
+				}
 			}
+			imgDone := atomic.AddInt32(&imagesDone, 1)
+			progress.Emit(ProgressEvent{Stage: "images", Region: rid, Done: int(imgDone), Total: total})
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			// VPC / 子网 / 安全组 / 密钥对 / 机型配置
+			syncVpcResources(cache, credentialID, req.SecretID, req.SecretKey, rid, rClient, zones)
 		}(regionID)
 	}
 	wg.Wait()
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	// 3. 同步实例
-	return syncInstances(db, client, req.DefaultRegion)
+	return syncInstances(ctx, cache, credentialID, client, req.DefaultRegion, progress)
 }
 
-func syncRegionsToDB(db *sql.DB, regions []*cvm.RegionInfo) error {
-	tx, _ := db.Begin()
-	defer tx.Rollback()
-	_, _ = tx.Exec("DELETE FROM regions")
-	stmt, _ := tx.Prepare("INSERT INTO regions (region, region_name, region_state, updated_at) VALUES (?, ?, ?, strftime('%s','now'))")
+func toRegions(regions []*cvm.RegionInfo) []Region {
+	out := make([]Region, 0, len(regions))
 	for _, r := range regions {
-		_, _ = stmt.Exec(str(r.Region), str(r.RegionName), str(r.RegionState))
+		out = append(out, Region{Region: str(r.Region), RegionName: str(r.RegionName), RegionState: str(r.RegionState)})
 	}
-	return tx.Commit()
+	return out
 }
 
-func syncZonesToDB(db *sql.DB, regionID string, zones []*cvm.ZoneInfo) error {
-	tx, _ := db.Begin()
-	defer tx.Rollback()
-	_, _ = tx.Exec("DELETE FROM zones WHERE region = ?", regionID)
-	stmt, _ := tx.Prepare("INSERT INTO zones (zone, region, zone_name, zone_state, updated_at) VALUES (?, ?, ?, ?, strftime('%s','now'))")
+func toZones(zones []*cvm.ZoneInfo) []Zone {
+	out := make([]Zone, 0, len(zones))
 	for _, z := range zones {
-		_, _ = stmt.Exec(str(z.Zone), regionID, str(z.ZoneName), str(z.ZoneState))
+		out = append(out, Zone{Zone: str(z.Zone), ZoneName: str(z.ZoneName), ZoneState: str(z.ZoneState)})
 	}
-	return tx.Commit()
+	return out
 }
 
-func syncImagesToDB(db *sql.DB, regionID string, images []*cvm.Image) error {
-	tx, _ := db.Begin()
-	defer tx.Rollback()
-	_, _ = tx.Exec("DELETE FROM images WHERE region = ? AND image_type = 'PUBLIC_IMAGE'", regionID)
-	stmt, _ := tx.Prepare("INSERT INTO images (image_id, image_name, image_type, platform, region, created_time, updated_at) VALUES (?, ?, ?, ?, ?, ?, strftime('%s','now'))")
+func toImages(images []*cvm.Image) []Image {
+	out := make([]Image, 0, len(images))
 	for _, img := range images {
-		_, _ = stmt.Exec(str(img.ImageId), str(img.ImageName), "PUBLIC_IMAGE", str(img.Platform), regionID, str(img.CreatedTime))
+		out = append(out, Image{ImageID: str(img.ImageId), ImageName: str(img.ImageName), ImageType: "PUBLIC_IMAGE", Platform: str(img.Platform), CreatedTime: str(img.CreatedTime)})
 	}
-	return tx.Commit()
+	return out
 }
 
-func syncInstances(db *sql.DB, client *cvm.Client, defaultRegion string) error {
-	_, _ = db.Exec("UPDATE instances SET status='-1', updated_at=strftime('%s','now') WHERE status != '-1'")
+func syncInstances(ctx context.Context, cache Cache, credentialID string, client *cvm.Client, defaultRegion string, progress *progressReporter) error {
+	_ = cache.MarkInstancesStale(credentialID)
 	var offset uint64 = 0
 	const limit uint64 = 100
+	var total int
 	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		req := cvm.NewDescribeInstancesRequest()
 		req.Offset = common.Int64Ptr(int64(offset))
 		req.Limit = common.Int64Ptr(int64(limit))
@@ -210,11 +365,6 @@ func syncInstances(db *sql.DB, client *cvm.Client, defaultRegion string) error {
 			break
 		}
 
-		tx, _ := db.Begin()
-		stmt, _ := tx.Prepare(`INSERT INTO instances (instance_id, instance_name, status, region, zone, instance_type, image_id, cpu, memory, private_ip, public_ip, created_time, updated_at) 
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, strftime('%s','now'))
-			ON CONFLICT(instance_id) DO UPDATE SET status=excluded.status, updated_at=strftime('%s','now')`)
-
 		for _, inst := range resp.Response.InstanceSet {
 			priv := ""
 			if len(inst.PrivateIpAddresses) > 0 {
@@ -229,9 +379,23 @@ func syncInstances(db *sql.DB, client *cvm.Client, defaultRegion string) error {
 				zone = str(inst.Placement.Zone)
 			}
 
-			_, _ = stmt.Exec(str(inst.InstanceId), str(inst.InstanceName), str(inst.InstanceState), defaultRegion, zone, str(inst.InstanceType), str(inst.ImageId), i64(inst.CPU), i64(inst.Memory), priv, pub, str(inst.CreatedTime))
+			_ = cache.UpsertInstance(credentialID, Instance{
+				InstanceID:   str(inst.InstanceId),
+				InstanceName: str(inst.InstanceName),
+				Status:       str(inst.InstanceState),
+				Region:       defaultRegion,
+				Zone:         zone,
+				InstanceType: str(inst.InstanceType),
+				ImageID:      str(inst.ImageId),
+				CPU:          i64(inst.CPU),
+				Memory:       i64(inst.Memory),
+				PrivateIP:    priv,
+				PublicIP:     pub,
+				CreatedTime:  str(inst.CreatedTime),
+			})
 		}
-		_ = tx.Commit()
+		total += len(resp.Response.InstanceSet)
+		progress.Emit(ProgressEvent{Stage: "instances", Done: total, Total: total})
 		if len(resp.Response.InstanceSet) < int(limit) {
 			break
 		}
@@ -0,0 +1,95 @@
+package main
+
+// Region is the cache-layer representation of a Tencent Cloud region,
+// decoupled from the cvm SDK types so that every Cache backend can share it.
+type Region struct {
+	Region      string
+	RegionName  string
+	RegionState string
+}
+
+// Zone is the cache-layer representation of an availability zone.
+type Zone struct {
+	Zone      string
+	ZoneName  string
+	ZoneState string
+}
+
+// Image is the cache-layer representation of a public image.
+type Image struct {
+	ImageID     string
+	ImageName   string
+	ImageType   string
+	Platform    string
+	CreatedTime string
+}
+
+// Vpc is the cache-layer representation of a VPC.
+type Vpc struct {
+	VpcID     string
+	Name      string
+	CidrBlock string
+	IsDefault bool
+}
+
+// Subnet is the cache-layer representation of a VPC subnet.
+type Subnet struct {
+	SubnetID         string
+	VpcID            string
+	Name             string
+	CidrBlock        string
+	Zone             string
+	AvailableIPCount int64
+}
+
+// SecurityGroup is the cache-layer representation of a security group.
+type SecurityGroup struct {
+	SecurityGroupID string
+	Name            string
+	Description     string
+}
+
+// KeyPair is the cache-layer representation of an SSH key pair.
+type KeyPair struct {
+	KeyID       string
+	KeyName     string
+	Description string
+}
+
+// InstanceTypeConfig is the cache-layer representation of a CPU/memory
+// combination available for a given zone, used to validate instance
+// creation requests.
+type InstanceTypeConfig struct {
+	Zone           string
+	InstanceType   string
+	CPU            int64
+	Memory         int64
+	InstanceFamily string
+}
+
+// Credential is a stored Tencent Cloud account. SecretKeyEncrypted holds
+// the AES-GCM ciphertext produced by encryptSecretKey, never the raw key.
+type Credential struct {
+	ID                 string
+	Name               string
+	SecretID           string
+	SecretKeyEncrypted string
+	DefaultRegion      string
+	CreatedAt          int64
+}
+
+// Instance is the cache-layer representation of a CVM instance.
+type Instance struct {
+	InstanceID   string
+	InstanceName string
+	Status       string
+	Region       string
+	Zone         string
+	InstanceType string
+	ImageID      string
+	CPU          int64
+	Memory       int64
+	PrivateIP    string
+	PublicIP     string
+	CreatedTime  string
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ProgressEvent describes one step of a running preload job. The same
+// event stream feeds both the /preload_all/stream SSE endpoint and a
+// terminal progress-bar mode, so the two front-ends never drift apart.
+type ProgressEvent struct {
+	Stage  string `json:"stage"`
+	Region string `json:"region,omitempty"`
+	Done   int    `json:"done"`
+	Total  int    `json:"total"`
+}
+
+// progressReporter fans ProgressEvents out to every subscriber without
+// letting a slow consumer block the preload goroutine. A nil
+// *progressReporter is valid and simply discards events, so callers that
+// don't care about progress (e.g. the scheduler) can pass nil.
+type progressReporter struct {
+	mu          sync.Mutex
+	last        ProgressEvent
+	subscribers map[chan ProgressEvent]struct{}
+}
+
+func newProgressReporter() *progressReporter {
+	return &progressReporter{subscribers: make(map[chan ProgressEvent]struct{})}
+}
+
+func (p *progressReporter) Subscribe() chan ProgressEvent {
+	ch := make(chan ProgressEvent, 32)
+	if p == nil {
+		return ch
+	}
+	p.mu.Lock()
+	p.subscribers[ch] = struct{}{}
+	p.mu.Unlock()
+	return ch
+}
+
+func (p *progressReporter) Unsubscribe(ch chan ProgressEvent) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	delete(p.subscribers, ch)
+	p.mu.Unlock()
+	close(ch)
+}
+
+func (p *progressReporter) Emit(ev ProgressEvent) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.last = ev
+	for ch := range p.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			// slow consumer, drop rather than block the preload goroutine
+		}
+	}
+	p.mu.Unlock()
+}
+
+var jobIDCounter int64
+
+func nextJobID() string {
+	return "job-" + strconv.FormatInt(atomic.AddInt64(&jobIDCounter, 1), 10)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// syncJob is one periodic task driven by its own ticker. staleAfter is the
+// watchdog threshold: if a job hasn't finished a run within staleAfter of
+// its last success, it's considered stuck and gets force-restarted.
+type syncJob struct {
+	name       string
+	interval   time.Duration
+	staleAfter time.Duration
+	fn         func() error
+
+	mu          sync.Mutex
+	running     bool
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastErr     error
+	nextRun     time.Time
+}
+
+// scheduler runs the four sync jobs (regions/zones/images/instances) on
+// independent tickers so callers don't have to poke /preload_all by hand.
+// It mirrors the CheckManyTask watchdog pattern: a stuck job is detected by
+// comparing elapsed time against interval*3 and force re-run.
+type scheduler struct {
+	cache         Cache
+	credentialID  string
+	secretID      string
+	secretKey     string
+	defaultRegion string
+
+	mu      sync.Mutex
+	regions []string // region IDs discovered by the regions job, consumed by zones/images
+
+	jobs map[string]*syncJob
+}
+
+// schedulerIntervals configures the ticker period for each job; zero
+// disables that job's ticker.
+type schedulerIntervals struct {
+	Regions   time.Duration
+	Zones     time.Duration
+	Images    time.Duration
+	Instances time.Duration
+}
+
+func newScheduler(cache Cache, secretID, secretKey, defaultRegion string, intervals schedulerIntervals) *scheduler {
+	s := &scheduler{
+		cache:         cache,
+		credentialID:  credentialNamespace(PreloadRequest{SecretID: secretID}),
+		secretID:      secretID,
+		secretKey:     secretKey,
+		defaultRegion: defaultRegion,
+		jobs:          make(map[string]*syncJob),
+	}
+
+	now := time.Now()
+	s.jobs["regions"] = &syncJob{name: "regions", interval: intervals.Regions, staleAfter: intervals.Regions * 3, fn: s.runRegions, lastSuccess: now}
+	s.jobs["zones"] = &syncJob{name: "zones", interval: intervals.Zones, staleAfter: intervals.Zones * 3, fn: s.runZones, lastSuccess: now}
+	s.jobs["images"] = &syncJob{name: "images", interval: intervals.Images, staleAfter: intervals.Images * 3, fn: s.runImages, lastSuccess: now}
+	s.jobs["instances"] = &syncJob{name: "instances", interval: intervals.Instances, staleAfter: intervals.Instances * 3, fn: s.runInstances, lastSuccess: now}
+	return s
+}
+
+// Start launches one ticker goroutine and one watchdog goroutine per job.
+// It does not block.
+func (s *scheduler) Start() {
+	for _, job := range s.jobs {
+		if job.interval <= 0 {
+			log.Printf("[sync] job %s disabled (interval=0)", job.name)
+			continue
+		}
+		go s.tickerLoop(job)
+		go s.watchdogLoop(job)
+	}
+}
+
+func (s *scheduler) tickerLoop(job *syncJob) {
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+	s.runJob(job)
+	for range ticker.C {
+		s.runJob(job)
+	}
+}
+
+func (s *scheduler) watchdogLoop(job *syncJob) {
+	if job.staleAfter <= 0 {
+		return
+	}
+	ticker := time.NewTicker(job.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		job.mu.Lock()
+		stale := job.running && time.Since(job.lastSuccess) > job.staleAfter
+		job.mu.Unlock()
+		if stale {
+			log.Printf("[sync] WARNING: job %s looks stuck (no success in %s), clearing in-flight marker and forcing re-run", job.name, job.staleAfter)
+			job.mu.Lock()
+			job.running = false
+			job.mu.Unlock()
+			s.runJob(job)
+		}
+	}
+}
+
+// runJob executes fn once, unless a previous run of the same job is still
+// in flight. Manual /sync/trigger calls go through this too.
+func (s *scheduler) runJob(job *syncJob) error {
+	job.mu.Lock()
+	if job.running {
+		job.mu.Unlock()
+		return fmt.Errorf("job %s already running", job.name)
+	}
+	job.running = true
+	job.mu.Unlock()
+
+	log.Printf("[sync] job %s starting", job.name)
+	err := job.fn()
+
+	job.mu.Lock()
+	job.running = false
+	job.lastRun = time.Now()
+	job.lastErr = err
+	if err == nil {
+		job.lastSuccess = job.lastRun
+	}
+	if job.interval > 0 {
+		job.nextRun = job.lastRun.Add(job.interval)
+	}
+	job.mu.Unlock()
+
+	if err != nil {
+		log.Printf("[sync] job %s failed: %v", job.name, err)
+	} else {
+		log.Printf("[sync] job %s finished", job.name)
+	}
+	return err
+}
+
+func (s *scheduler) client(region string) (*cvm.Client, error) {
+	return newCvmClient(s.secretID, s.secretKey, region)
+}
+
+func (s *scheduler) runRegions() error {
+	client, err := s.client(s.defaultRegion)
+	if err != nil {
+		return err
+	}
+	regions, err := fetchRegions(client)
+	if err != nil {
+		return err
+	}
+	if err := s.cache.PutRegions(s.credentialID, toRegions(regions)); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(regions))
+	for _, r := range regions {
+		if rid := str(r.Region); rid != "" {
+			ids = append(ids, rid)
+		}
+	}
+	s.mu.Lock()
+	s.regions = ids
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *scheduler) regionIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.regions) > 0 {
+		return append([]string(nil), s.regions...)
+	}
+	return []string{s.defaultRegion}
+}
+
+func (s *scheduler) runZones() error {
+	var firstErr error
+	for _, rid := range s.regionIDs() {
+		client, err := s.client(rid)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		resp, err := client.DescribeZones(cvm.NewDescribeZonesRequest())
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		if err := s.cache.PutZones(s.credentialID, rid, toZones(resp.Response.ZoneSet)); err != nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *scheduler) runImages() error {
+	var firstErr error
+	for _, rid := range s.regionIDs() {
+		client, err := s.client(rid)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		req := cvm.NewDescribeImagesRequest()
+		req.Limit = common.Uint64Ptr(60)
+		req.Filters = []*cvm.Filter{{Name: common.StringPtr("image-type"), Values: []*string{common.StringPtr("PUBLIC_IMAGE")}}}
+		resp, err := client.DescribeImages(req)
+		if err != nil {
+			firstErr = err
+			continue
+		}
+		if err := s.cache.PutImages(s.credentialID, rid, toImages(resp.Response.ImageSet)); err != nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *scheduler) runInstances() error {
+	client, err := s.client(s.defaultRegion)
+	if err != nil {
+		return err
+	}
+	return syncInstances(context.Background(), s.cache, s.credentialID, client, s.defaultRegion, nil)
+}
+
+// jobStatus is the JSON shape returned by /sync/status.
+type jobStatus struct {
+	LastRun string `json:"last_run,omitempty"`
+	NextRun string `json:"next_run,omitempty"`
+	LastErr string `json:"last_error,omitempty"`
+	Running bool   `json:"running"`
+}
+
+func (s *scheduler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	out := make(map[string]jobStatus, len(s.jobs))
+	for name, job := range s.jobs {
+		job.mu.Lock()
+		st := jobStatus{Running: job.running}
+		if !job.lastRun.IsZero() {
+			st.LastRun = job.lastRun.Format(time.RFC3339)
+		}
+		if !job.nextRun.IsZero() {
+			st.NextRun = job.nextRun.Format(time.RFC3339)
+		}
+		if job.lastErr != nil {
+			st.LastErr = job.lastErr.Error()
+		}
+		job.mu.Unlock()
+		out[name] = st
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+func (s *scheduler) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("job")
+	job, ok := s.jobs[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown job %q", name), http.StatusBadRequest)
+		return
+	}
+
+	go s.runJob(job)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "triggered", "job": name})
+}
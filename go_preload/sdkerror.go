@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+// sdkErrorStatus maps a Tencent Cloud SDK error code to the HTTP status
+// the instance lifecycle API should return, following the error code
+// families documented by Tencent Cloud (https://cloud.tencent.com/document/api/213/15688).
+func sdkErrorStatus(err error) int {
+	sdkErr, ok := err.(*errors.TencentCloudSDKError)
+	if !ok {
+		return http.StatusBadGateway
+	}
+	switch {
+	case strings.HasPrefix(sdkErr.Code, "InvalidParameter"):
+		return http.StatusBadRequest
+	case strings.HasPrefix(sdkErr.Code, "ResourceNotFound"):
+		return http.StatusNotFound
+	case strings.HasPrefix(sdkErr.Code, "AuthFailure"), strings.HasPrefix(sdkErr.Code, "UnauthorizedOperation"):
+		return http.StatusForbidden
+	case strings.HasPrefix(sdkErr.Code, "LimitExceeded"), strings.HasPrefix(sdkErr.Code, "RequestLimitExceeded"), strings.HasPrefix(sdkErr.Code, "ResourceInsufficient"):
+		return http.StatusTooManyRequests
+	case strings.HasPrefix(sdkErr.Code, "ResourceInUse"), strings.HasPrefix(sdkErr.Code, "ResourceUnavailable"), strings.HasPrefix(sdkErr.Code, "InstanceState"):
+		return http.StatusConflict
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	tcerrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+func TestSdkErrorStatus(t *testing.T) {
+	cases := []struct {
+		code string
+		want int
+	}{
+		{"InvalidParameterValue.InvalidImageId", http.StatusBadRequest},
+		{"ResourceNotFound.InstanceNotFound", http.StatusNotFound},
+		{"AuthFailure.SecretIdNotFound", http.StatusForbidden},
+		{"UnauthorizedOperation", http.StatusForbidden},
+		{"LimitExceeded", http.StatusTooManyRequests},
+		{"RequestLimitExceeded", http.StatusTooManyRequests},
+		{"ResourceInsufficient.Cvm", http.StatusTooManyRequests},
+		{"ResourceInUse.Instance", http.StatusConflict},
+		{"ResourceUnavailable.InstanceState", http.StatusConflict},
+		{"InstanceStateNotSupported", http.StatusConflict},
+		{"InternalError", http.StatusBadGateway},
+	}
+	for _, c := range cases {
+		sdkErr := tcerrors.NewTencentCloudSDKError(c.code, "boom", "req-1")
+		if got := sdkErrorStatus(sdkErr); got != c.want {
+			t.Errorf("sdkErrorStatus(%q) = %d, want %d", c.code, got, c.want)
+		}
+	}
+}
+
+func TestSdkErrorStatusNonSDKError(t *testing.T) {
+	if got := sdkErrorStatus(errors.New("plain error")); got != http.StatusBadGateway {
+		t.Errorf("sdkErrorStatus(non-SDK error) = %d, want %d", got, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// handlePreloadAllStream is the SSE counterpart of /preload_all: it streams
+// one "progress" event per completed region/stage, then a terminal "done"
+// or "error" event. The job_id from the first event can be passed to
+// /preload_all/cancel to abort the run.
+func (s *server) handlePreloadAllStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var req PreloadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	req, err := s.resolveCredential(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.DefaultRegion == "" {
+		req.DefaultRegion = "ap-beijing"
+	}
+	credentialID := credentialNamespace(req)
+
+	cache := s.cache
+
+	job, ctx := newPreloadJob(r.Context())
+	defer finishPreloadJob(job)
+
+	sub := job.progress.Subscribe()
+	defer job.progress.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	writeSSE(w, "job", map[string]string{"job_id": job.id})
+	flusher.Flush()
+
+	lock := s.locks.get(credentialID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- runFullPreload(ctx, cache, credentialID, req, job.progress) }()
+
+	for {
+		select {
+		case ev := <-sub:
+			writeSSE(w, "progress", ev)
+			flusher.Flush()
+		case err := <-done:
+			if err != nil {
+				writeSSE(w, "error", map[string]string{"message": err.Error()})
+			} else {
+				writeSSE(w, "done", map[string]string{"message": "success"})
+			}
+			flusher.Flush()
+			return
+		case <-r.Context().Done():
+			log.Printf("[sse] client disconnected, cancelling job %s", job.id)
+			return
+		}
+	}
+}
+
+// handlePreloadAllCancel cancels a run started by /preload_all/stream (or
+// /preload_all) by its job_id.
+func (s *server) handlePreloadAllCancel(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Query().Get("job_id")
+	job, ok := lookupPreloadJob(jobID)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown job_id %q", jobID), http.StatusNotFound)
+		return
+	}
+	job.cancel()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "cancelling", "job_id": jobID})
+}
+
+func writeSSE(w http.ResponseWriter, event string, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+}
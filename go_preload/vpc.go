@@ -0,0 +1,187 @@
+package main
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+// vpcDescribePageSize is the page size used when paginating the VPC
+// Describe* calls below. Without it, Tencent's default page size (20)
+// silently truncates accounts with more vpcs/subnets/security groups than
+// that, and validateCreate would reject legitimate ids beyond page one.
+const vpcDescribePageSize = 100
+
+func newVpcClient(secretID, secretKey, region string) (*vpc.Client, error) {
+	cred := common.NewCredential(secretID, secretKey)
+	cpf := profile.NewClientProfile()
+	return vpc.NewClient(cred, region, cpf)
+}
+
+// describeAllVpcs pages through DescribeVpcs, mirroring the offset/limit
+// loop syncInstances already uses for DescribeInstances.
+func describeAllVpcs(client *vpc.Client) ([]*vpc.Vpc, error) {
+	var all []*vpc.Vpc
+	var offset uint64
+	for {
+		req := vpc.NewDescribeVpcsRequest()
+		req.Offset = common.StringPtr(strconv.FormatUint(offset, 10))
+		req.Limit = common.StringPtr(strconv.Itoa(vpcDescribePageSize))
+		resp, err := client.DescribeVpcs(req)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Response.VpcSet...)
+		if len(resp.Response.VpcSet) < vpcDescribePageSize {
+			break
+		}
+		offset += vpcDescribePageSize
+	}
+	return all, nil
+}
+
+func describeAllSubnets(client *vpc.Client) ([]*vpc.Subnet, error) {
+	var all []*vpc.Subnet
+	var offset uint64
+	for {
+		req := vpc.NewDescribeSubnetsRequest()
+		req.Offset = common.StringPtr(strconv.FormatUint(offset, 10))
+		req.Limit = common.StringPtr(strconv.Itoa(vpcDescribePageSize))
+		resp, err := client.DescribeSubnets(req)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Response.SubnetSet...)
+		if len(resp.Response.SubnetSet) < vpcDescribePageSize {
+			break
+		}
+		offset += vpcDescribePageSize
+	}
+	return all, nil
+}
+
+func describeAllSecurityGroups(client *vpc.Client) ([]*vpc.SecurityGroup, error) {
+	var all []*vpc.SecurityGroup
+	var offset uint64
+	for {
+		req := vpc.NewDescribeSecurityGroupsRequest()
+		req.Offset = common.StringPtr(strconv.FormatUint(offset, 10))
+		req.Limit = common.StringPtr(strconv.Itoa(vpcDescribePageSize))
+		resp, err := client.DescribeSecurityGroups(req)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Response.SecurityGroupSet...)
+		if len(resp.Response.SecurityGroupSet) < vpcDescribePageSize {
+			break
+		}
+		offset += vpcDescribePageSize
+	}
+	return all, nil
+}
+
+func toVpcs(vpcs []*vpc.Vpc) []Vpc {
+	out := make([]Vpc, 0, len(vpcs))
+	for _, v := range vpcs {
+		isDefault := v.IsDefault != nil && *v.IsDefault
+		out = append(out, Vpc{VpcID: str(v.VpcId), Name: str(v.VpcName), CidrBlock: str(v.CidrBlock), IsDefault: isDefault})
+	}
+	return out
+}
+
+func toSubnets(subnets []*vpc.Subnet) []Subnet {
+	out := make([]Subnet, 0, len(subnets))
+	for _, sn := range subnets {
+		var available int64
+		if sn.AvailableIpAddressCount != nil {
+			available = int64(*sn.AvailableIpAddressCount)
+		}
+		out = append(out, Subnet{
+			SubnetID:         str(sn.SubnetId),
+			VpcID:            str(sn.VpcId),
+			Name:             str(sn.SubnetName),
+			CidrBlock:        str(sn.CidrBlock),
+			Zone:             str(sn.Zone),
+			AvailableIPCount: available,
+		})
+	}
+	return out
+}
+
+func toSecurityGroups(sgs []*vpc.SecurityGroup) []SecurityGroup {
+	out := make([]SecurityGroup, 0, len(sgs))
+	for _, sg := range sgs {
+		out = append(out, SecurityGroup{SecurityGroupID: str(sg.SecurityGroupId), Name: str(sg.SecurityGroupName), Description: str(sg.SecurityGroupDesc)})
+	}
+	return out
+}
+
+func toKeyPairs(keyPairs []*cvm.KeyPair) []KeyPair {
+	out := make([]KeyPair, 0, len(keyPairs))
+	for _, kp := range keyPairs {
+		out = append(out, KeyPair{KeyID: str(kp.KeyId), KeyName: str(kp.KeyName), Description: str(kp.Description)})
+	}
+	return out
+}
+
+func toInstanceTypeConfigs(configs []*cvm.InstanceTypeConfig) []InstanceTypeConfig {
+	out := make([]InstanceTypeConfig, 0, len(configs))
+	for _, cfg := range configs {
+		out = append(out, InstanceTypeConfig{
+			Zone:           str(cfg.Zone),
+			InstanceType:   str(cfg.InstanceType),
+			CPU:            i64(cfg.CPU),
+			Memory:         i64(cfg.Memory),
+			InstanceFamily: str(cfg.InstanceFamily),
+		})
+	}
+	return out
+}
+
+// syncVpcResources fans the VPC/subnet/security-group/key-pair/instance-type
+// calls out for one region. It's called from inside the same semaphore slot
+// the region's zones/images calls already hold, so all of these share one
+// bounded worker pool per credential.
+func syncVpcResources(cache Cache, credentialID, secretID, secretKey, rid string, cvmClient *cvm.Client, zones []*cvm.ZoneInfo) {
+	if vClient, err := newVpcClient(secretID, secretKey, rid); err == nil {
+		if vpcs, err := describeAllVpcs(vClient); err == nil {
+			if err := cache.PutVpcs(credentialID, rid, toVpcs(vpcs)); err != nil {
+				log.Printf("cache.PutVpcs(%s) failed: %v", rid, err)
+			}
+		}
+		if subnets, err := describeAllSubnets(vClient); err == nil {
+			if err := cache.PutSubnets(credentialID, rid, toSubnets(subnets)); err != nil {
+				log.Printf("cache.PutSubnets(%s) failed: %v", rid, err)
+			}
+		}
+		if sgs, err := describeAllSecurityGroups(vClient); err == nil {
+			if err := cache.PutSecurityGroups(credentialID, rid, toSecurityGroups(sgs)); err != nil {
+				log.Printf("cache.PutSecurityGroups(%s) failed: %v", rid, err)
+			}
+		}
+	}
+
+	if resp, err := cvmClient.DescribeKeyPairs(cvm.NewDescribeKeyPairsRequest()); err == nil {
+		if err := cache.PutKeyPairs(credentialID, rid, toKeyPairs(resp.Response.KeyPairSet)); err != nil {
+			log.Printf("cache.PutKeyPairs(%s) failed: %v", rid, err)
+		}
+	}
+
+	for _, z := range zones {
+		zone := str(z.Zone)
+		if zone == "" {
+			continue
+		}
+		req := cvm.NewDescribeInstanceTypeConfigsRequest()
+		req.Filters = []*cvm.Filter{{Name: common.StringPtr("zone"), Values: []*string{common.StringPtr(zone)}}}
+		if resp, err := cvmClient.DescribeInstanceTypeConfigs(req); err == nil {
+			if err := cache.PutInstanceTypeConfigs(credentialID, rid, zone, toInstanceTypeConfigs(resp.Response.InstanceTypeConfigSet)); err != nil {
+				log.Printf("cache.PutInstanceTypeConfigs(%s/%s) failed: %v", rid, zone, err)
+			}
+		}
+	}
+}